@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+)
+
+// AttachmentConfig selects a file to attach to a notification email.
+// Type "log" with Path "-" attaches the captured backup log passed on the
+// NotifyEvent; type "file" attaches the file at Path from disk.
+type AttachmentConfig struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+type mimeAttachment struct {
+	Filename string
+	Data     []byte
+}
+
+func buildAttachments(cfgs []AttachmentConfig, log string) ([]mimeAttachment, error) {
+	attachments := make([]mimeAttachment, 0, len(cfgs))
+	for _, a := range cfgs {
+		switch a.Type {
+		case "log":
+			if a.Path != "" && a.Path != "-" {
+				return nil, fmt.Errorf("attachment type \"log\" only supports path \"-\", got %q", a.Path)
+			}
+			attachments = append(attachments, mimeAttachment{Filename: "backup.log", Data: []byte(log)})
+		case "file":
+			data, err := os.ReadFile(a.Path)
+			if err != nil {
+				return nil, fmt.Errorf("attachment %s: %w", a.Path, err)
+			}
+			attachments = append(attachments, mimeAttachment{Filename: filepath.Base(a.Path), Data: data})
+		default:
+			return nil, fmt.Errorf("unknown attachment type %q", a.Type)
+		}
+	}
+	return attachments, nil
+}
+
+// buildMIMEMessage assembles a multipart/mixed email with a plain-text body
+// part and one base64-encoded part per attachment.
+func buildMIMEMessage(from, to, subject, body string, attachments []mimeAttachment) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		from, to, subject, writer.Boundary())
+
+	bodyHeader := textproto.MIMEHeader{}
+	bodyHeader.Set("Content-Type", "text/plain; charset=utf-8")
+	bodyPart, err := writer.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := bodyPart.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/octet-stream")
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, a.Filename))
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		enc := base64.NewEncoder(base64.StdEncoding, part)
+		if _, err := enc.Write(a.Data); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return append([]byte(headers), buf.Bytes()...), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMIMEMessage(t *testing.T) {
+	attachments := []mimeAttachment{
+		{Filename: "backup.log", Data: []byte("line one\nline two\n")},
+	}
+
+	msg, err := buildMIMEMessage("from@example.com", "to@example.com", "subject line", "body text", attachments)
+	if err != nil {
+		t.Fatalf("buildMIMEMessage returned error: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(msg)))
+	if err != nil {
+		t.Fatalf("mail.ReadMessage: %v", err)
+	}
+	if got := parsed.Header.Get("Subject"); got != "subject line" {
+		t.Fatalf("got subject %q, want %q", got, "subject line")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("mime.ParseMediaType: %v", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("got media type %q, want multipart", mediaType)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading body part: %v", err)
+	}
+	bodyData, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatalf("reading body part contents: %v", err)
+	}
+	if string(bodyData) != "body text" {
+		t.Fatalf("got body %q, want %q", bodyData, "body text")
+	}
+
+	part, err = reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading attachment part: %v", err)
+	}
+	if enc := part.Header.Get("Content-Transfer-Encoding"); enc != "base64" {
+		t.Fatalf("got Content-Transfer-Encoding %q, want base64", enc)
+	}
+	raw, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatalf("reading attachment part contents: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		t.Fatalf("decoding base64 attachment: %v", err)
+	}
+	if string(decoded) != "line one\nline two\n" {
+		t.Fatalf("got attachment contents %q, want %q", decoded, "line one\nline two\n")
+	}
+}
+
+func TestBuildAttachmentsLogRejectsArbitraryPath(t *testing.T) {
+	_, err := buildAttachments([]AttachmentConfig{{Type: "log", Path: "/etc/shadow"}}, "log contents")
+	if err == nil {
+		t.Fatal("expected error for log attachment with a non-\"-\" path, got nil")
+	}
+}
+
+func TestBuildAttachmentsLog(t *testing.T) {
+	attachments, err := buildAttachments([]AttachmentConfig{{Type: "log", Path: "-"}}, "log contents")
+	if err != nil {
+		t.Fatalf("buildAttachments returned error: %v", err)
+	}
+	if len(attachments) != 1 || string(attachments[0].Data) != "log contents" {
+		t.Fatalf("got %+v, want a single attachment with the captured log", attachments)
+	}
+}
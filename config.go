@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 )
 
 type MailSendConfig struct {
@@ -13,49 +14,134 @@ type MailSendConfig struct {
 }
 
 type MailTemplate struct {
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+	SubjectFile string `json:"subject_file"`
+	BodyFile    string `json:"body_file"`
 }
 
+// TLSMode selects how the SMTP transport establishes (or skips) encryption.
+type TLSMode string
+
+const (
+	TLSModeNone     TLSMode = "none"
+	TLSModeSTARTTLS TLSMode = "starttls"
+	TLSModeTLS      TLSMode = "tls"
+)
+
+// SMTPAuthMethod selects which SMTP AUTH mechanism is used to authenticate.
+type SMTPAuthMethod string
+
+const (
+	SMTPAuthPlain   SMTPAuthMethod = "plain"
+	SMTPAuthLogin   SMTPAuthMethod = "login"
+	SMTPAuthCRAMMD5 SMTPAuthMethod = "cram-md5"
+	SMTPAuthNone    SMTPAuthMethod = "none"
+)
+
 type SMTPConfig struct {
-	Host     string           `json:"host"`
-	Port     string           `json:"port"`
-	Username string           `json:"username"`
-	Password string           `json:"password"`
-	Insecure bool             `json:"insecure"`
-	Mails    []MailSendConfig `json:"mails"`
-	Template *MailTemplate    `json:"template"`
+	Host        string             `json:"host"`
+	Port        string             `json:"port"`
+	ServerName  string             `json:"servername"`
+	Username    string             `json:"username"`
+	Password    string             `json:"password"`
+	Insecure    bool               `json:"insecure"`
+	TLSMode     TLSMode            `json:"tls_mode"`
+	AuthMethod  SMTPAuthMethod     `json:"auth_method"`
+	Mails       []MailSendConfig   `json:"mails"`
+	Template    *MailTemplate      `json:"template"`
+	Attachments []AttachmentConfig `json:"attachments"`
+}
+
+func (s *SMTPConfig) valid() bool {
+	mailCfgValid := s.Host != "" && s.Port != "" && s.Username != "" && s.Password != ""
+	if len(s.Mails) == 0 {
+		return false
+	}
+	for i := range s.Mails {
+		mailCfgValid = mailCfgValid && (s.Mails[i].From != "" && s.Mails[i].To != "")
+	}
+
+	switch s.AuthMethod {
+	case SMTPAuthCRAMMD5, SMTPAuthPlain, SMTPAuthLogin:
+		mailCfgValid = mailCfgValid && s.Username != "" && s.Password != ""
+	case SMTPAuthNone, "":
+		// no credentials required
+	default:
+		return false
+	}
+
+	if s.TLSMode == TLSModeNone && s.AuthMethod != SMTPAuthNone && s.AuthMethod != "" {
+		fmt.Printf("warning: SMTP auth method %q configured over an unencrypted (tls_mode=none) connection\n", s.AuthMethod)
+	}
+
+	for i := range s.Attachments {
+		switch s.Attachments[i].Type {
+		case "log":
+			if s.Attachments[i].Path != "" && s.Attachments[i].Path != "-" {
+				return false
+			}
+		case "file":
+			mailCfgValid = mailCfgValid && s.Attachments[i].Path != ""
+		default:
+			return false
+		}
+	}
+
+	return mailCfgValid
+}
+
+// serverName returns the hostname used for TLS certificate verification,
+// falling back to Host so connecting via IP with an explicit ServerName works.
+func (s *SMTPConfig) serverName() string {
+	if s.ServerName != "" {
+		return s.ServerName
+	}
+	return s.Host
 }
 
 type Config struct {
-	BaseURL          string      `json:"baseurl"`
-	CertFingerprint  string      `json:"certfingerprint"`
-	AuthID           string      `json:"authid"`
-	Secret           string      `json:"secret"`
-	Datastore        string      `json:"datastore"`
-	Namespace        string      `json:"namespace"`
-	BackupID         string      `json:"backup-id"`
-	BackupSourceDir  string      `json:"backupdir"`
-	BackupStreamName string 	 `json:"backupstreamname"`
-	PxarOut          string      `json:"pxarout"`
-	SMTP             *SMTPConfig `json:"smtp"`
+	BaseURL          string               `json:"baseurl"`
+	CertFingerprint  string               `json:"certfingerprint"`
+	AuthID           string               `json:"authid"`
+	Secret           string               `json:"secret"`
+	Datastore        string               `json:"datastore"`
+	Namespace        string               `json:"namespace"`
+	BackupID         string               `json:"backup-id"`
+	BackupSourceDir  string               `json:"backupdir"`
+	BackupStreamName string               `json:"backupstreamname"`
+	PxarOut          string               `json:"pxarout"`
+	SMTP             *SMTPConfig          `json:"smtp"`
+	Notifications    []NotificationConfig `json:"notifications"`
+	Jobs             []JobConfig          `json:"jobs"`
+	Parallel         int                  `json:"parallel"`
 }
 
+// valid reports whether c has enough information to run. Fields may have
+// been populated from a flag, an env var (PBC_*), a config file, or an
+// @file:/@env: indirection resolved by resolveSecrets — in that order of
+// precedence — so a "missing" field here means none of those sources set it.
+// It assumes collapseJobs() has already run, so c.Jobs always holds at least
+// the synthetic job built from the legacy top-level fields.
 func (c *Config) valid() bool {
-	baseValid := c.BaseURL != "" && c.AuthID != "" && c.Secret != "" && c.Datastore != "" && ( c.BackupSourceDir != "" || c.BackupStreamName != "" )
+	baseValid := c.BaseURL != "" && c.AuthID != "" && c.Secret != "" && len(c.Jobs) > 0
 	if !baseValid {
 		return baseValid
 	}
-
-	if c.SMTP != nil {
-		mailCfgValid := c.SMTP.Host != "" && c.SMTP.Port != "" && c.SMTP.Username != "" && c.SMTP.Password != ""
-		if len(c.SMTP.Mails) == 0 {
+	for i := range c.Jobs {
+		if !c.Jobs[i].valid() {
 			return false
 		}
-		for i := range c.SMTP.Mails {
-			mailCfgValid = mailCfgValid && (c.SMTP.Mails[i].From != "" && c.SMTP.Mails[i].To != "")
+	}
+
+	if c.SMTP != nil && !c.SMTP.valid() {
+		return false
+	}
+
+	for i := range c.Notifications {
+		if !c.Notifications[i].valid() {
+			return false
 		}
-		return mailCfgValid
 	}
 
 	return true
@@ -73,22 +159,34 @@ func loadConfig() *Config {
 	backupSourceDirFlag := flag.String("backupdir", "", "Backup source directory, must not be symlink")
 	backupStreamNameFlag := flag.String("backupstream", "", "Filename for stream backup")
 	pxarOutFlag := flag.String("pxarout", "", "Output PXAR archive for debug purposes (optional)")
+	parallelFlag := flag.Int("parallel", 1, "Number of jobs to run concurrently when the config defines multiple jobs (optional)")
 
 	mailHostFlag := flag.String("mail-host", "", "mail notification system: mail server host(optional)")
 	mailPortFlag := flag.String("mail-port", "", "mail notification system: mail server port(optional)")
 	mailUsernameFlag := flag.String("mail-username", "", "mail notification system: mail server username(optional)")
 	mailPasswordFlag := flag.String("mail-password", "", "mail notification system: mail server password(optional)")
 	mailInsecureFlag := flag.Bool("mail-insecure", false, "mail notification system: allow insecure communications(optional)")
+	mailTLSModeFlag := flag.String("mail-tls-mode", "", "mail notification system: TLS transport mode: none|starttls|tls(optional)")
+	mailAuthFlag := flag.String("mail-auth", "", "mail notification system: SMTP auth method: plain|login|cram-md5|none(optional)")
 	mailFromFlag := flag.String("mail-from", "", "mail notification system: sender mail(optional)")
 	mailToFlag := flag.String("mail-to", "", "mail notification system: receiver mail(optional)")
 	mailSubjectTemplateFlag := flag.String("mail-subject-template", "", "mail notification system: mail subject template(optional)")
 	mailBodyTemplateFlag := flag.String("mail-body-template", "", "mail notification system: mail body template(optional)")
+	mailSubjectTemplateFileFlag := flag.String("mail-subject-template-file", "", "mail notification system: path to mail subject template file(optional)")
+	mailBodyTemplateFileFlag := flag.String("mail-body-template-file", "", "mail notification system: path to mail body template file(optional)")
 
 	configFile := flag.String("config", "", "Path to JSON config file. If this flag is provided all the others will override the loaded config file")
 
 	// Parse command line flags
 	flag.Parse()
 
+	parallelFlagSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "parallel" {
+			parallelFlagSet = true
+		}
+	})
+
 	config := &Config{}
 	if *configFile != "" {
 		file, err := os.ReadFile(*configFile)
@@ -103,36 +201,51 @@ func loadConfig() *Config {
 		}
 	}
 
-	if *baseURLFlag != "" {
-		config.BaseURL = *baseURLFlag
+	// Precedence for every setting below is: explicit flag > env var >
+	// config file value (already loaded above) > zero value.
+	if v := envFlag(*baseURLFlag, "PBC_BASEURL"); v != "" {
+		config.BaseURL = v
 	}
-	if *certFingerprintFlag != "" {
-		config.CertFingerprint = *certFingerprintFlag
+	if v := envFlag(*certFingerprintFlag, "PBC_CERTFINGERPRINT"); v != "" {
+		config.CertFingerprint = v
 	}
-	if *authIDFlag != "" {
-		config.AuthID = *authIDFlag
+	if v := envFlag(*authIDFlag, "PBC_AUTHID"); v != "" {
+		config.AuthID = v
 	}
-	if *secretFlag != "" {
-		config.Secret = *secretFlag
+	if v := envFlag(*secretFlag, "PBC_SECRET"); v != "" {
+		config.Secret = v
 	}
-	if *datastoreFlag != "" {
-		config.Datastore = *datastoreFlag
+	if v := envFlag(*datastoreFlag, "PBC_DATASTORE"); v != "" {
+		config.Datastore = v
 	}
-	if *namespaceFlag != "" {
-		config.Namespace = *namespaceFlag
+	if v := envFlag(*namespaceFlag, "PBC_NAMESPACE"); v != "" {
+		config.Namespace = v
 	}
-	if *backupIDFlag != "" {
-		config.BackupID = *backupIDFlag
+	if v := envFlag(*backupIDFlag, "PBC_BACKUP_ID"); v != "" {
+		config.BackupID = v
 	}
-	if *backupSourceDirFlag != "" {
-		config.BackupSourceDir = *backupSourceDirFlag
+	if v := envFlag(*backupSourceDirFlag, "PBC_BACKUPDIR"); v != "" {
+		config.BackupSourceDir = v
 	}
 
-	if *backupStreamNameFlag != "" {
-		config.BackupStreamName = *backupStreamNameFlag
+	if v := envFlag(*backupStreamNameFlag, "PBC_BACKUPSTREAM"); v != "" {
+		config.BackupStreamName = v
 	}
-	if *pxarOutFlag != "" {
-		config.PxarOut = *pxarOutFlag
+	if v := envFlag(*pxarOutFlag, "PBC_PXAROUT"); v != "" {
+		config.PxarOut = v
+	}
+	switch {
+	case parallelFlagSet:
+		config.Parallel = *parallelFlag
+	case os.Getenv("PBC_PARALLEL") != "":
+		n, err := strconv.Atoi(os.Getenv("PBC_PARALLEL"))
+		if err != nil {
+			fmt.Printf("Error parsing PBC_PARALLEL: %v\n", err)
+			os.Exit(1)
+		}
+		config.Parallel = n
+	case config.Parallel == 0:
+		config.Parallel = *parallelFlag
 	}
 
 	initSmtpConfigIfNeeded := func() {
@@ -146,42 +259,72 @@ func loadConfig() *Config {
 			config.SMTP.Mails = append(config.SMTP.Mails, MailSendConfig{})
 		}
 	}
+	initMailTemplateIfNeeded := func() {
+		initSmtpConfigIfNeeded()
+		if config.SMTP.Template == nil {
+			config.SMTP.Template = &MailTemplate{}
+		}
+	}
 
-	if *mailHostFlag != "" {
+	if v := envFlag(*mailHostFlag, "PBC_MAIL_HOST"); v != "" {
+		initSmtpConfigIfNeeded()
+		config.SMTP.Host = v
+	}
+	if v := envFlag(*mailPortFlag, "PBC_MAIL_PORT"); v != "" {
 		initSmtpConfigIfNeeded()
-		config.SMTP.Host = *mailHostFlag
+		config.SMTP.Port = v
 	}
-	if *mailPortFlag != "" {
+	if v := envFlag(*mailUsernameFlag, "PBC_MAIL_USERNAME"); v != "" {
 		initSmtpConfigIfNeeded()
-		config.SMTP.Port = *mailPortFlag
+		config.SMTP.Username = v
 	}
-	if *mailUsernameFlag != "" {
+	if v := envFlag(*mailPasswordFlag, "PBC_MAIL_PASSWORD"); v != "" {
 		initSmtpConfigIfNeeded()
-		config.SMTP.Username = *mailUsernameFlag
+		config.SMTP.Password = v
 	}
-	if *mailPasswordFlag != "" {
+	if *mailInsecureFlag || os.Getenv("PBC_MAIL_INSECURE") != "" {
 		initSmtpConfigIfNeeded()
-		config.SMTP.Password = *mailPasswordFlag
+		config.SMTP.Insecure = true
 	}
-	if *mailInsecureFlag {
+	if v := envFlag(*mailTLSModeFlag, "PBC_MAIL_TLS_MODE"); v != "" {
 		initSmtpConfigIfNeeded()
-		config.SMTP.Insecure = *mailInsecureFlag
+		config.SMTP.TLSMode = TLSMode(v)
 	}
-	if *mailFromFlag != "" {
+	if v := envFlag(*mailAuthFlag, "PBC_MAIL_AUTH"); v != "" {
+		initSmtpConfigIfNeeded()
+		config.SMTP.AuthMethod = SMTPAuthMethod(v)
+	}
+	if v := envFlag(*mailFromFlag, "PBC_MAIL_FROM"); v != "" {
 		initMailConfsIfNeeded()
-		config.SMTP.Mails[0].From = *mailFromFlag
+		config.SMTP.Mails[0].From = v
 	}
-	if *mailToFlag != "" {
+	if v := envFlag(*mailToFlag, "PBC_MAIL_TO"); v != "" {
 		initMailConfsIfNeeded()
-		config.SMTP.Mails[0].To = *mailToFlag
+		config.SMTP.Mails[0].To = v
 	}
-	if *mailSubjectTemplateFlag != "" {
-		initSmtpConfigIfNeeded()
-		config.SMTP.Template.Subject = *mailSubjectTemplateFlag
+	if v := envFlag(*mailSubjectTemplateFlag, "PBC_MAIL_SUBJECT_TEMPLATE"); v != "" {
+		initMailTemplateIfNeeded()
+		config.SMTP.Template.Subject = v
 	}
-	if *mailBodyTemplateFlag != "" {
-		initSmtpConfigIfNeeded()
-		config.SMTP.Template.Body = *mailBodyTemplateFlag
+	if v := envFlag(*mailBodyTemplateFlag, "PBC_MAIL_BODY_TEMPLATE"); v != "" {
+		initMailTemplateIfNeeded()
+		config.SMTP.Template.Body = v
+	}
+	if v := envFlag(*mailSubjectTemplateFileFlag, "PBC_MAIL_SUBJECT_TEMPLATE_FILE"); v != "" {
+		initMailTemplateIfNeeded()
+		config.SMTP.Template.SubjectFile = v
+	}
+	if v := envFlag(*mailBodyTemplateFileFlag, "PBC_MAIL_BODY_TEMPLATE_FILE"); v != "" {
+		initMailTemplateIfNeeded()
+		config.SMTP.Template.BodyFile = v
+	}
+
+	config.applySMTPCompatShim()
+	config.collapseJobs()
+
+	if err := config.resolveSecrets(); err != nil {
+		fmt.Printf("Error resolving secrets: %v\n", err)
+		os.Exit(1)
 	}
 
 	return config
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// postNtfy sends a message to an ntfy topic via HTTP POST, using the title,
+// priority and tags headers documented by ntfy, with optional HTTP Basic auth.
+func postNtfy(baseURL, topic, title, body, priority string, tags []string, username, password string) error {
+	url := strings.TrimSuffix(baseURL, "/") + "/" + topic
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ntfy request: %w", err)
+	}
+	req.Header.Set("Title", title)
+	if priority != "" {
+		req.Header.Set("Priority", priority)
+	}
+	if len(tags) > 0 {
+		req.Header.Set("Tags", strings.Join(tags, ","))
+	}
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ntfy post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy post: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// postWebhook POSTs the event as a JSON payload to url with the configured headers.
+func postWebhook(url string, headers map[string]string, event NotifyEvent) error {
+	payload, err := json.Marshal(struct {
+		Type          EventType `json:"type"`
+		Datastore     string    `json:"datastore"`
+		Namespace     string    `json:"namespace"`
+		BackupID      string    `json:"backup_id"`
+		SnapshotID    string    `json:"snapshot_id,omitempty"`
+		BytesUploaded int64     `json:"bytes_uploaded,omitempty"`
+		DurationSecs  float64   `json:"duration_seconds,omitempty"`
+		Error         string    `json:"error,omitempty"`
+	}{
+		Type:          event.Type,
+		Datastore:     event.Datastore,
+		Namespace:     event.Namespace,
+		BackupID:      event.BackupID,
+		SnapshotID:    event.SnapshotID,
+		BytesUploaded: event.BytesUploaded,
+		DurationSecs:  event.Duration.Seconds(),
+		Error:         errString(event.Err),
+	})
+	if err != nil {
+		return fmt.Errorf("webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook post: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostNtfy(t *testing.T) {
+	var gotMethod, gotPath, gotTitle, gotPriority, gotTags, gotBody string
+	var gotUser, gotPass string
+	var gotAuthOK bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotTitle = r.Header.Get("Title")
+		gotPriority = r.Header.Get("Priority")
+		gotTags = r.Header.Get("Tags")
+		gotUser, gotPass, gotAuthOK = r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := postNtfy(server.URL, "backups", "Backup succeeded", "all good", "high", []string{"white_check_mark", "backup"}, "user", "pass")
+	if err != nil {
+		t.Fatalf("postNtfy returned error: %v", err)
+	}
+
+	if gotMethod != http.MethodPost {
+		t.Errorf("got method %q, want POST", gotMethod)
+	}
+	if gotPath != "/backups" {
+		t.Errorf("got path %q, want /backups", gotPath)
+	}
+	if gotTitle != "Backup succeeded" {
+		t.Errorf("got Title header %q, want %q", gotTitle, "Backup succeeded")
+	}
+	if gotPriority != "high" {
+		t.Errorf("got Priority header %q, want %q", gotPriority, "high")
+	}
+	if gotTags != "white_check_mark,backup" {
+		t.Errorf("got Tags header %q, want %q", gotTags, "white_check_mark,backup")
+	}
+	if !gotAuthOK || gotUser != "user" || gotPass != "pass" {
+		t.Errorf("got basic auth (%q, %q, %v), want (%q, %q, true)", gotUser, gotPass, gotAuthOK, "user", "pass")
+	}
+	if gotBody != "all good" {
+		t.Errorf("got body %q, want %q", gotBody, "all good")
+	}
+}
+
+func TestPostNtfyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := postNtfy(server.URL, "backups", "title", "body", "", nil, "", ""); err == nil {
+		t.Fatal("expected an error for a non-2xx response, got nil")
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var gotContentType, gotCustomHeader string
+	var gotPayload map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		gotCustomHeader = r.Header.Get("X-Api-Key")
+		if err := json.NewDecoder(r.Body).Decode(&gotPayload); err != nil {
+			t.Errorf("decoding webhook payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := NotifyEvent{
+		Type:          EventFailure,
+		Datastore:     "ds",
+		Namespace:     "ns",
+		BackupID:      "host1",
+		SnapshotID:    "snap1",
+		BytesUploaded: 1024,
+		Duration:      2 * time.Second,
+		Err:           errors.New("upload failed"),
+	}
+
+	err := postWebhook(server.URL, map[string]string{"X-Api-Key": "secret"}, event)
+	if err != nil {
+		t.Fatalf("postWebhook returned error: %v", err)
+	}
+
+	if gotContentType != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", gotContentType)
+	}
+	if gotCustomHeader != "secret" {
+		t.Errorf("got X-Api-Key %q, want %q", gotCustomHeader, "secret")
+	}
+	if gotPayload["type"] != "failure" || gotPayload["datastore"] != "ds" || gotPayload["backup_id"] != "host1" {
+		t.Errorf("unexpected payload: %+v", gotPayload)
+	}
+	if gotPayload["error"] != "upload failed" {
+		t.Errorf("got error field %v, want %q", gotPayload["error"], "upload failed")
+	}
+}
+
+func TestPostWebhookErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if err := postWebhook(server.URL, nil, NotifyEvent{Type: EventSuccess}); err == nil {
+		t.Fatal("expected an error for a non-2xx response, got nil")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// JobConfig describes a single backup stream within a (possibly multi-job)
+// run. The top-level Config fields mirror JobConfig and are collapsed into a
+// synthetic one-element Jobs slice by collapseJobs() for back-compat.
+type JobConfig struct {
+	Datastore        string               `json:"datastore"`
+	Namespace        string               `json:"namespace"`
+	BackupID         string               `json:"backup-id"`
+	BackupSourceDir  string               `json:"backupdir"`
+	BackupStreamName string               `json:"backupstreamname"`
+	PxarOut          string               `json:"pxarout"`
+	Notifications    []NotificationConfig `json:"notifications,omitempty"`
+}
+
+func (j *JobConfig) valid() bool {
+	if j.Datastore == "" || (j.BackupSourceDir == "" && j.BackupStreamName == "") {
+		return false
+	}
+	for i := range j.Notifications {
+		if !j.Notifications[i].valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// collapseJobs ensures c.Jobs is populated: if the config uses the legacy
+// single-job top-level fields, it synthesizes a one-element Jobs slice from
+// them so callers only ever need to range over c.Jobs.
+func (c *Config) collapseJobs() {
+	if len(c.Jobs) != 0 {
+		return
+	}
+	c.Jobs = []JobConfig{{
+		Datastore:        c.Datastore,
+		Namespace:        c.Namespace,
+		BackupID:         c.BackupID,
+		BackupSourceDir:  c.BackupSourceDir,
+		BackupStreamName: c.BackupStreamName,
+		PxarOut:          c.PxarOut,
+	}}
+}
+
+// jobNotifiers returns the notifier entries for a job: its own
+// Notifications if set, otherwise the top-level config's.
+func (c *Config) jobNotifiers(job JobConfig) ([]notifierEntry, error) {
+	if len(job.Notifications) == 0 {
+		return c.buildNotifiers()
+	}
+	scoped := *c
+	scoped.Notifications = job.Notifications
+	return scoped.buildNotifiers()
+}
+
+// runJobs runs fn for every job in jobs, sequentially when parallel <= 1,
+// or with up to parallel jobs running concurrently otherwise. It returns an
+// error aggregating every job failure, or nil if all jobs succeeded.
+func runJobs(jobs []JobConfig, parallel int, fn func(JobConfig) error) error {
+	if parallel <= 1 {
+		var errs []error
+		for _, job := range jobs {
+			if err := fn(job); err != nil {
+				errs = append(errs, fmt.Errorf("job %s/%s: %w", job.Datastore, job.BackupID, err))
+			}
+		}
+		return joinJobErrors(errs)
+	}
+
+	sem := make(chan struct{}, parallel)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+
+	for _, job := range jobs {
+		job := job
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(job); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("job %s/%s: %w", job.Datastore, job.BackupID, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return joinJobErrors(errs)
+}
+
+func joinJobErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%d job(s) failed:", len(errs))
+	for _, err := range errs {
+		msg += " " + err.Error() + ";"
+	}
+	return fmt.Errorf("%s", msg)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunJobsSequentialAggregatesErrors(t *testing.T) {
+	jobs := []JobConfig{
+		{Datastore: "ds1", BackupID: "a"},
+		{Datastore: "ds2", BackupID: "b"},
+		{Datastore: "ds3", BackupID: "c"},
+	}
+
+	var ran []string
+	err := runJobs(jobs, 1, func(job JobConfig) error {
+		ran = append(ran, job.BackupID)
+		if job.BackupID == "b" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if len(ran) != 3 || ran[0] != "a" || ran[1] != "b" || ran[2] != "c" {
+		t.Fatalf("jobs did not run sequentially in order: %v", ran)
+	}
+	if err == nil {
+		t.Fatal("expected an aggregated error, got nil")
+	}
+	if !strings.Contains(err.Error(), "ds2/b") {
+		t.Fatalf("error %q does not reference the failing job", err.Error())
+	}
+}
+
+func TestRunJobsSequentialAllSucceed(t *testing.T) {
+	jobs := []JobConfig{{Datastore: "ds1", BackupID: "a"}, {Datastore: "ds2", BackupID: "b"}}
+
+	err := runJobs(jobs, 1, func(JobConfig) error { return nil })
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestRunJobsParallelRunsConcurrentlyAndAggregatesErrors(t *testing.T) {
+	jobs := []JobConfig{
+		{Datastore: "ds1", BackupID: "a"},
+		{Datastore: "ds2", BackupID: "b"},
+		{Datastore: "ds3", BackupID: "c"},
+	}
+
+	var inFlight int32
+	var maxInFlight int32
+	err := runJobs(jobs, 3, func(job JobConfig) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		if job.BackupID == "c" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if maxInFlight < 2 {
+		t.Fatalf("expected jobs to run concurrently, max in flight was %d", maxInFlight)
+	}
+	if err == nil || !strings.Contains(err.Error(), "ds3/c") {
+		t.Fatalf("expected aggregated error referencing ds3/c, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+func main() {
+	config := loadConfig()
+	if !config.valid() {
+		fmt.Println("Invalid configuration, see -help for the available flags")
+		os.Exit(1)
+	}
+
+	err := runJobs(config.Jobs, config.Parallel, func(job JobConfig) error {
+		return runBackupJob(config, job)
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// runBackupJob drives a single JobConfig: it notifies on start, runs the
+// backup, then notifies on success or failure with the resulting metadata.
+func runBackupJob(config *Config, job JobConfig) error {
+	entries, err := config.jobNotifiers(job)
+	if err != nil {
+		return fmt.Errorf("building notifiers: %w", err)
+	}
+
+	if err := fanOutNotify(entries, NotifyEvent{
+		Type:      EventStart,
+		Datastore: job.Datastore,
+		Namespace: job.Namespace,
+		BackupID:  job.BackupID,
+	}); err != nil {
+		fmt.Printf("notify start: %v\n", err)
+	}
+
+	start := time.Now()
+	result, backupErr := performBackup(job)
+	duration := time.Since(start)
+
+	event := NotifyEvent{
+		Datastore:     job.Datastore,
+		Namespace:     job.Namespace,
+		BackupID:      job.BackupID,
+		SnapshotID:    result.SnapshotID,
+		SnapshotTime:  start,
+		BytesUploaded: result.BytesUploaded,
+		BytesReused:   result.BytesReused,
+		ChunkCount:    result.ChunkCount,
+		Duration:      duration,
+		Log:           result.Log,
+	}
+
+	if backupErr != nil {
+		event.Type = EventFailure
+		event.Err = backupErr
+		if err := fanOutNotify(entries, event); err != nil {
+			fmt.Printf("notify failure: %v\n", err)
+		}
+		return backupErr
+	}
+
+	event.Type = EventSuccess
+	if err := fanOutNotify(entries, event); err != nil {
+		fmt.Printf("notify success: %v\n", err)
+	}
+	return nil
+}
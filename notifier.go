@@ -0,0 +1,363 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// sanitizeHeaderValue strips CR/LF from a value about to be interpolated
+// into a raw mail header line, preventing header/SMTP injection via
+// templated subjects built from backup metadata (e.g. an error message).
+func sanitizeHeaderValue(v string) string {
+	v = strings.ReplaceAll(v, "\r", " ")
+	return strings.ReplaceAll(v, "\n", " ")
+}
+
+type EventType string
+
+const (
+	EventStart   EventType = "start"
+	EventSuccess EventType = "success"
+	EventFailure EventType = "failure"
+)
+
+type NotifyEvent struct {
+	Type          EventType
+	Datastore     string
+	Namespace     string
+	BackupID      string
+	SnapshotID    string
+	SnapshotTime  time.Time
+	BytesUploaded int64
+	BytesReused   int64
+	ChunkCount    int
+	Duration      time.Duration
+	Log           string
+	Err           error
+}
+
+type Notifier interface {
+	Notify(event NotifyEvent) error
+}
+
+type NtfyConfig struct {
+	URL      string   `json:"url"`
+	Topic    string   `json:"topic"`
+	Priority string   `json:"priority"`
+	Tags     []string `json:"tags"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+}
+
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+type NotificationConfig struct {
+	Type      string         `json:"type"`
+	OnSuccess bool           `json:"on_success"`
+	OnFailure bool           `json:"on_failure"`
+	SMTP      *SMTPConfig    `json:"smtp,omitempty"`
+	Ntfy      *NtfyConfig    `json:"ntfy,omitempty"`
+	Webhook   *WebhookConfig `json:"webhook,omitempty"`
+}
+
+func (n *NotificationConfig) valid() bool {
+	switch n.Type {
+	case "smtp":
+		return n.SMTP != nil && n.SMTP.valid()
+	case "ntfy":
+		return n.Ntfy != nil && n.Ntfy.URL != "" && n.Ntfy.Topic != ""
+	case "webhook":
+		return n.Webhook != nil && n.Webhook.URL != ""
+	default:
+		return false
+	}
+}
+
+type notifierEntry struct {
+	notifier  Notifier
+	onSuccess bool
+	onFailure bool
+}
+
+// buildNotifiers turns the configured Notifications slice (plus the legacy
+// SMTP compatibility shim) into the concrete notifiers used by fanOutNotify.
+func (c *Config) buildNotifiers() ([]notifierEntry, error) {
+	entries := make([]notifierEntry, 0, len(c.Notifications))
+	for i := range c.Notifications {
+		nc := c.Notifications[i]
+		var notifier Notifier
+		switch nc.Type {
+		case "smtp":
+			notifier = newSMTPNotifier(nc.SMTP)
+		case "ntfy":
+			notifier = newNtfyNotifier(nc.Ntfy)
+		case "webhook":
+			notifier = newWebhookNotifier(nc.Webhook)
+		default:
+			return nil, fmt.Errorf("unknown notification type %q", nc.Type)
+		}
+		entries = append(entries, notifierEntry{notifier: notifier, onSuccess: nc.OnSuccess, onFailure: nc.OnFailure})
+	}
+	return entries, nil
+}
+
+// applySMTPCompatShim maps the legacy top-level SMTP field onto a single
+// "smtp" entry in Notifications, so existing configs keep working unchanged.
+func (c *Config) applySMTPCompatShim() {
+	if c.SMTP == nil {
+		return
+	}
+	for i := range c.Notifications {
+		if c.Notifications[i].Type == "smtp" {
+			return
+		}
+	}
+	c.Notifications = append(c.Notifications, NotificationConfig{
+		Type:      "smtp",
+		OnSuccess: true,
+		OnFailure: true,
+		SMTP:      c.SMTP,
+	})
+}
+
+// fanOutNotify sends event to every configured notifier concurrently and
+// aggregates any errors returned. EventStart is unconditional (there is no
+// on_start gate); EventSuccess/EventFailure are only sent to notifiers with
+// the matching on_success/on_failure flag set.
+func fanOutNotify(entries []notifierEntry, event NotifyEvent) error {
+	type result struct {
+		err error
+	}
+	send := make([]notifierEntry, 0, len(entries))
+	for _, e := range entries {
+		switch event.Type {
+		case EventSuccess:
+			if !e.onSuccess {
+				continue
+			}
+		case EventFailure:
+			if !e.onFailure {
+				continue
+			}
+		}
+		send = append(send, e)
+	}
+	if len(send) == 0 {
+		return nil
+	}
+
+	results := make(chan result, len(send))
+	for _, e := range send {
+		go func(e notifierEntry) {
+			results <- result{err: e.notifier.Notify(event)}
+		}(e)
+	}
+
+	var errs []error
+	for range send {
+		if r := <-results; r.err != nil {
+			errs = append(errs, r.err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%d of %d notifiers failed:", len(errs), len(send))
+	for _, err := range errs {
+		msg += " " + err.Error() + ";"
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+type smtpNotifier struct {
+	cfg *SMTPConfig
+}
+
+func newSMTPNotifier(cfg *SMTPConfig) Notifier {
+	return &smtpNotifier{cfg: cfg}
+}
+
+func (s *smtpNotifier) Notify(event NotifyEvent) error {
+	subject, body, err := renderMailTemplate(s.cfg.Template, eventContext(event))
+	if err != nil {
+		return fmt.Errorf("smtp render template: %w", err)
+	}
+	subject = sanitizeHeaderValue(subject)
+
+	attachments, err := buildAttachments(s.cfg.Attachments, event.Log)
+	if err != nil {
+		return fmt.Errorf("smtp attachments: %w", err)
+	}
+
+	client, err := s.dial()
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	defer client.Close()
+
+	auth, err := s.auth()
+	if err != nil {
+		return fmt.Errorf("smtp auth: %w", err)
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	for _, m := range s.cfg.Mails {
+		var msg []byte
+		if len(attachments) > 0 {
+			msg, err = buildMIMEMessage(m.From, m.To, subject, body, attachments)
+			if err != nil {
+				return fmt.Errorf("smtp build message: %w", err)
+			}
+		} else {
+			msg = []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.From, m.To, subject, body))
+		}
+		if err := sendMessage(client, m.From, []string{m.To}, msg); err != nil {
+			return fmt.Errorf("smtp notify to %s: %w", m.To, err)
+		}
+	}
+	return client.Quit()
+}
+
+// dial connects to the configured SMTP server using the selected TLSMode:
+// implicit TLS, STARTTLS upgrade, or a plain unencrypted connection.
+func (s *smtpNotifier) dial() (*smtp.Client, error) {
+	addr := fmt.Sprintf("%s:%s", s.cfg.Host, s.cfg.Port)
+
+	switch s.cfg.TLSMode {
+	case TLSModeTLS:
+		conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: s.cfg.serverName(), InsecureSkipVerify: s.cfg.Insecure})
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(conn, s.cfg.Host)
+	case TLSModeSTARTTLS:
+		client, err := smtp.Dial(addr)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.serverName(), InsecureSkipVerify: s.cfg.Insecure}); err != nil {
+			client.Close()
+			return nil, err
+		}
+		return client, nil
+	default:
+		return smtp.Dial(addr)
+	}
+}
+
+// auth builds the smtp.Auth for the configured AuthMethod, or nil for "none".
+func (s *smtpNotifier) auth() (smtp.Auth, error) {
+	switch s.cfg.AuthMethod {
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(s.cfg.Username, s.cfg.Password), nil
+	case SMTPAuthLogin:
+		return &loginAuth{username: s.cfg.Username, password: s.cfg.Password}, nil
+	case SMTPAuthNone:
+		return nil, nil
+	case SMTPAuthPlain, "":
+		return smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host), nil
+	default:
+		return nil, fmt.Errorf("unknown smtp auth method %q", s.cfg.AuthMethod)
+	}
+}
+
+func sendMessage(client *smtp.Client, from string, to []string, msg []byte) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// loginAuth implements the SMTP LOGIN authentication mechanism, which the
+// standard library's net/smtp does not provide out of the box.
+type loginAuth struct {
+	username string
+	password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	default:
+		return nil, errors.New("unexpected LOGIN server challenge: " + string(fromServer))
+	}
+}
+
+func renderEvent(event NotifyEvent) (subject, body string) {
+	switch event.Type {
+	case EventStart:
+		return fmt.Sprintf("Backup started: %s/%s", event.Datastore, event.BackupID),
+			fmt.Sprintf("Backup of %s/%s started.", event.Datastore, event.BackupID)
+	case EventSuccess:
+		return fmt.Sprintf("Backup succeeded: %s/%s", event.Datastore, event.BackupID),
+			fmt.Sprintf("Backup of %s/%s succeeded.\nSnapshot: %s\nBytes uploaded: %d\nDuration: %s",
+				event.Datastore, event.BackupID, event.SnapshotID, event.BytesUploaded, event.Duration)
+	case EventFailure:
+		return fmt.Sprintf("Backup failed: %s/%s", event.Datastore, event.BackupID),
+			fmt.Sprintf("Backup of %s/%s failed: %v", event.Datastore, event.BackupID, event.Err)
+	default:
+		return "Backup notification", ""
+	}
+}
+
+type ntfyNotifier struct {
+	cfg *NtfyConfig
+}
+
+func newNtfyNotifier(cfg *NtfyConfig) Notifier {
+	return &ntfyNotifier{cfg: cfg}
+}
+
+func (n *ntfyNotifier) Notify(event NotifyEvent) error {
+	subject, body := renderEvent(event)
+	url := n.cfg.URL
+	if url == "" {
+		url = "https://ntfy.sh"
+	}
+	return postNtfy(url, n.cfg.Topic, subject, body, n.cfg.Priority, n.cfg.Tags, n.cfg.Username, n.cfg.Password)
+}
+
+type webhookNotifier struct {
+	cfg *WebhookConfig
+}
+
+func newWebhookNotifier(cfg *WebhookConfig) Notifier {
+	return &webhookNotifier{cfg: cfg}
+}
+
+func (w *webhookNotifier) Notify(event NotifyEvent) error {
+	return postWebhook(w.cfg.URL, w.cfg.Headers, event)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// notifierFunc adapts a plain function to the Notifier interface for tests.
+type notifierFunc func(NotifyEvent) error
+
+func (f notifierFunc) Notify(event NotifyEvent) error { return f(event) }
+
+func TestFanOutNotifyAggregatesErrors(t *testing.T) {
+	var mu sync.Mutex
+	var called []string
+
+	ok := notifierEntry{onSuccess: true, onFailure: true, notifier: notifierFunc(func(NotifyEvent) error {
+		mu.Lock()
+		called = append(called, "ok")
+		mu.Unlock()
+		return nil
+	})}
+	failing := notifierEntry{onSuccess: true, onFailure: true, notifier: notifierFunc(func(NotifyEvent) error {
+		mu.Lock()
+		called = append(called, "failing")
+		mu.Unlock()
+		return errors.New("boom")
+	})}
+
+	err := fanOutNotify([]notifierEntry{ok, failing}, NotifyEvent{Type: EventSuccess})
+	if err == nil {
+		t.Fatal("expected an aggregated error, got nil")
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("error %q does not contain the underlying notifier error", err.Error())
+	}
+	if len(called) != 2 {
+		t.Fatalf("expected both notifiers to be called, got %v", called)
+	}
+}
+
+func TestFanOutNotifyNoFailures(t *testing.T) {
+	entry := notifierEntry{onSuccess: true, onFailure: true, notifier: notifierFunc(func(NotifyEvent) error { return nil })}
+	if err := fanOutNotify([]notifierEntry{entry}, NotifyEvent{Type: EventSuccess}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestFanOutNotifyGatesOnSuccessAndFailure(t *testing.T) {
+	var calledSuccess, calledFailure bool
+
+	successOnly := notifierEntry{onSuccess: true, onFailure: false, notifier: notifierFunc(func(NotifyEvent) error {
+		calledSuccess = true
+		return nil
+	})}
+	failureOnly := notifierEntry{onSuccess: false, onFailure: true, notifier: notifierFunc(func(NotifyEvent) error {
+		calledFailure = true
+		return nil
+	})}
+
+	if err := fanOutNotify([]notifierEntry{successOnly, failureOnly}, NotifyEvent{Type: EventSuccess}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !calledSuccess {
+		t.Error("expected the on_success notifier to be called for a success event")
+	}
+	if calledFailure {
+		t.Error("expected the on_failure-only notifier to be skipped for a success event")
+	}
+
+	calledSuccess, calledFailure = false, false
+	if err := fanOutNotify([]notifierEntry{successOnly, failureOnly}, NotifyEvent{Type: EventFailure}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calledSuccess {
+		t.Error("expected the on_success-only notifier to be skipped for a failure event")
+	}
+	if !calledFailure {
+		t.Error("expected the on_failure notifier to be called for a failure event")
+	}
+}
+
+func TestFanOutNotifyStartIsUnconditional(t *testing.T) {
+	var called bool
+	disabled := notifierEntry{onSuccess: false, onFailure: false, notifier: notifierFunc(func(NotifyEvent) error {
+		called = true
+		return nil
+	})}
+
+	if err := fanOutNotify([]notifierEntry{disabled}, NotifyEvent{Type: EventStart}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Error("expected a start event to reach a notifier even with on_success/on_failure both false")
+	}
+}
+
+func TestApplySMTPCompatShim(t *testing.T) {
+	cfg := &Config{SMTP: &SMTPConfig{Host: "mail.example.com"}}
+
+	cfg.applySMTPCompatShim()
+
+	if len(cfg.Notifications) != 1 {
+		t.Fatalf("expected one notification entry, got %d", len(cfg.Notifications))
+	}
+	got := cfg.Notifications[0]
+	if got.Type != "smtp" || got.SMTP != cfg.SMTP || !got.OnSuccess || !got.OnFailure {
+		t.Fatalf("unexpected shimmed notification entry: %+v", got)
+	}
+}
+
+func TestApplySMTPCompatShimSkipsWhenSMTPNotificationAlreadyPresent(t *testing.T) {
+	existing := &SMTPConfig{Host: "existing.example.com"}
+	cfg := &Config{
+		SMTP:          &SMTPConfig{Host: "legacy.example.com"},
+		Notifications: []NotificationConfig{{Type: "smtp", SMTP: existing}},
+	}
+
+	cfg.applySMTPCompatShim()
+
+	if len(cfg.Notifications) != 1 || cfg.Notifications[0].SMTP != existing {
+		t.Fatalf("expected the existing smtp notification to be left untouched, got %+v", cfg.Notifications)
+	}
+}
+
+func TestApplySMTPCompatShimNoopWithoutSMTP(t *testing.T) {
+	cfg := &Config{}
+	cfg.applySMTPCompatShim()
+	if len(cfg.Notifications) != 0 {
+		t.Fatalf("expected no notifications, got %+v", cfg.Notifications)
+	}
+}
+
+func TestBuildNotifiersUnknownType(t *testing.T) {
+	cfg := &Config{Notifications: []NotificationConfig{{Type: "carrier-pigeon"}}}
+	if _, err := cfg.buildNotifiers(); err == nil {
+		t.Fatal("expected an error for an unknown notification type, got nil")
+	}
+}
+
+func TestBuildNotifiersSMTPNtfyWebhook(t *testing.T) {
+	cfg := &Config{Notifications: []NotificationConfig{
+		{Type: "smtp", SMTP: &SMTPConfig{Host: "mail.example.com"}},
+		{Type: "ntfy", Ntfy: &NtfyConfig{URL: "https://ntfy.sh", Topic: "backups"}},
+		{Type: "webhook", Webhook: &WebhookConfig{URL: "https://example.com/hook"}},
+	}}
+
+	entries, err := cfg.buildNotifiers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 notifier entries, got %d", len(entries))
+	}
+}
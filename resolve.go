@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	secretFilePrefix = "@file:"
+	secretEnvPrefix  = "@env:"
+)
+
+// resolveSecret expands "@file:/path" and "@env:NAME" indirections in a
+// config string value into the referenced secret, so secrets never have to
+// be stored in plaintext in the JSON config. Values without either prefix
+// are returned unchanged.
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := strings.TrimPrefix(value, secretFilePrefix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("resolving %s: %w", value, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("resolving %s: environment variable not set", value)
+		}
+		return val, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveSecrets resolves every @file:/@env: indirection in the config's
+// secret-bearing fields (Secret, and every configured notifier's
+// credentials: SMTP password, ntfy password, webhook header values) once at
+// startup.
+func (c *Config) resolveSecrets() error {
+	resolved, err := resolveSecret(c.Secret)
+	if err != nil {
+		return fmt.Errorf("secret: %w", err)
+	}
+	c.Secret = resolved
+
+	if c.SMTP != nil {
+		if err := resolveNotificationSecrets(&NotificationConfig{Type: "smtp", SMTP: c.SMTP}); err != nil {
+			return err
+		}
+	}
+	for i := range c.Notifications {
+		if err := resolveNotificationSecrets(&c.Notifications[i]); err != nil {
+			return err
+		}
+	}
+	for j := range c.Jobs {
+		for i := range c.Jobs[j].Notifications {
+			if err := resolveNotificationSecrets(&c.Jobs[j].Notifications[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveNotificationSecrets resolves the @file:/@env: indirections in
+// whichever notifier sub-config is set on nc.
+func resolveNotificationSecrets(nc *NotificationConfig) error {
+	if nc.SMTP != nil {
+		resolved, err := resolveSecret(nc.SMTP.Password)
+		if err != nil {
+			return fmt.Errorf("smtp password: %w", err)
+		}
+		nc.SMTP.Password = resolved
+	}
+	if nc.Ntfy != nil {
+		resolved, err := resolveSecret(nc.Ntfy.Password)
+		if err != nil {
+			return fmt.Errorf("ntfy password: %w", err)
+		}
+		nc.Ntfy.Password = resolved
+	}
+	if nc.Webhook != nil {
+		for key, value := range nc.Webhook.Headers {
+			resolved, err := resolveSecret(value)
+			if err != nil {
+				return fmt.Errorf("webhook header %s: %w", key, err)
+			}
+			nc.Webhook.Headers[key] = resolved
+		}
+	}
+	return nil
+}
+
+// envFlag returns the flag value if it is non-empty, otherwise the value of
+// the given environment variable, implementing the flag > env var > zero
+// value precedence used throughout loadConfig().
+func envFlag(flagValue, envName string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(envName)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecretPlainValue(t *testing.T) {
+	got, err := resolveSecret("plaintext")
+	if err != nil {
+		t.Fatalf("resolveSecret returned error: %v", err)
+	}
+	if got != "plaintext" {
+		t.Fatalf("got %q, want %q", got, "plaintext")
+	}
+}
+
+func TestResolveSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := resolveSecret("@file:" + path)
+	if err != nil {
+		t.Fatalf("resolveSecret returned error: %v", err)
+	}
+	if got != "s3cr3t" {
+		t.Fatalf("got %q, want %q", got, "s3cr3t")
+	}
+}
+
+func TestResolveSecretFileMissing(t *testing.T) {
+	if _, err := resolveSecret("@file:/does/not/exist"); err == nil {
+		t.Fatal("expected error for missing secret file, got nil")
+	}
+}
+
+func TestResolveSecretEnv(t *testing.T) {
+	t.Setenv("PBC_TEST_SECRET", "from-env")
+
+	got, err := resolveSecret("@env:PBC_TEST_SECRET")
+	if err != nil {
+		t.Fatalf("resolveSecret returned error: %v", err)
+	}
+	if got != "from-env" {
+		t.Fatalf("got %q, want %q", got, "from-env")
+	}
+}
+
+func TestResolveSecretEnvMissing(t *testing.T) {
+	os.Unsetenv("PBC_TEST_SECRET_UNSET")
+	if _, err := resolveSecret("@env:PBC_TEST_SECRET_UNSET"); err == nil {
+		t.Fatal("expected error for missing env var, got nil")
+	}
+}
+
+func TestResolveSecretsWebhookHeaders(t *testing.T) {
+	t.Setenv("PBC_TEST_TOKEN", "tok3n")
+
+	cfg := &Config{
+		AuthID:          "id",
+		Secret:          "secret",
+		BaseURL:         "https://pbs",
+		Datastore:       "ds",
+		BackupSourceDir: t.TempDir(),
+		Notifications: []NotificationConfig{{
+			Type: "webhook",
+			Webhook: &WebhookConfig{
+				URL:     "https://example.com/hook",
+				Headers: map[string]string{"Authorization": "@env:PBC_TEST_TOKEN"},
+			},
+		}},
+	}
+	cfg.collapseJobs()
+
+	if err := cfg.resolveSecrets(); err != nil {
+		t.Fatalf("resolveSecrets returned error: %v", err)
+	}
+	if got := cfg.Notifications[0].Webhook.Headers["Authorization"]; got != "tok3n" {
+		t.Fatalf("got %q, want %q", got, "tok3n")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSMTPNotifierAuthMethodSelection(t *testing.T) {
+	cases := []struct {
+		name       string
+		authMethod SMTPAuthMethod
+		wantNil    bool
+		wantType   string
+		wantErr    bool
+	}{
+		{name: "default is plain", authMethod: "", wantType: "*smtp.plainAuth"},
+		{name: "explicit plain", authMethod: SMTPAuthPlain, wantType: "*smtp.plainAuth"},
+		{name: "login", authMethod: SMTPAuthLogin, wantType: "*main.loginAuth"},
+		{name: "cram-md5", authMethod: SMTPAuthCRAMMD5, wantType: "*smtp.cramMD5Auth"},
+		{name: "none", authMethod: SMTPAuthNone, wantNil: true},
+		{name: "unknown", authMethod: SMTPAuthMethod("hmac-sha256"), wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier := &smtpNotifier{cfg: &SMTPConfig{
+				Host:       "mail.example.com",
+				Username:   "user",
+				Password:   "pass",
+				AuthMethod: tc.authMethod,
+			}}
+
+			auth, err := notifier.auth()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error for an unknown auth method, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantNil {
+				if auth != nil {
+					t.Fatalf("expected a nil smtp.Auth, got %T", auth)
+				}
+				return
+			}
+			if auth == nil {
+				t.Fatal("expected a non-nil smtp.Auth")
+			}
+			if got := fmt.Sprintf("%T", auth); got != tc.wantType {
+				t.Fatalf("got auth type %s, want %s", got, tc.wantType)
+			}
+		})
+	}
+}
+
+func TestSMTPConfigServerName(t *testing.T) {
+	cases := []struct {
+		name       string
+		host       string
+		serverName string
+		want       string
+	}{
+		{name: "falls back to host", host: "192.168.1.10", serverName: "", want: "192.168.1.10"},
+		{name: "explicit server name wins", host: "192.168.1.10", serverName: "pbs.example.com", want: "pbs.example.com"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &SMTPConfig{Host: tc.host, ServerName: tc.serverName}
+			if got := cfg.serverName(); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
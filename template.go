@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+)
+
+// TemplateContext exposes the backup metadata available to MailTemplate's
+// Subject/Body templates.
+type TemplateContext struct {
+	Hostname      string
+	Datastore     string
+	Namespace     string
+	BackupID      string
+	SnapshotID    string
+	SnapshotTime  time.Time
+	BytesUploaded int64
+	BytesReused   int64
+	ChunkCount    int
+	Duration      time.Duration
+	Status        string
+	Error         string
+}
+
+const (
+	defaultSubjectTemplate = `Backup {{.Status}}: {{.Datastore}}/{{.BackupID}}`
+	defaultBodyTemplate    = `Backup of {{.Datastore}}/{{.BackupID}} on {{.Hostname}} {{.Status}}.
+Namespace: {{.Namespace}}
+Snapshot: {{.SnapshotID}} ({{.SnapshotTime}})
+Bytes uploaded: {{.BytesUploaded}}
+Bytes reused: {{.BytesReused}}
+Chunks: {{.ChunkCount}}
+Duration: {{.Duration}}
+{{if .Error}}Error: {{.Error}}
+{{end}}`
+)
+
+func eventContext(event NotifyEvent) TemplateContext {
+	hostname, _ := os.Hostname()
+	errMsg := ""
+	if event.Err != nil {
+		errMsg = event.Err.Error()
+	}
+	return TemplateContext{
+		Hostname:      hostname,
+		Datastore:     event.Datastore,
+		Namespace:     event.Namespace,
+		BackupID:      event.BackupID,
+		SnapshotID:    event.SnapshotID,
+		SnapshotTime:  event.SnapshotTime,
+		BytesUploaded: event.BytesUploaded,
+		BytesReused:   event.BytesReused,
+		ChunkCount:    event.ChunkCount,
+		Duration:      event.Duration,
+		Status:        string(event.Type),
+		Error:         errMsg,
+	}
+}
+
+// renderMailTemplate renders tpl's subject/body against ctx, loading
+// SubjectFile/BodyFile from disk when set and falling back to the built-in
+// default template when tpl is nil or leaves a field empty.
+func renderMailTemplate(tpl *MailTemplate, ctx TemplateContext) (subject, body string, err error) {
+	subjectSrc, err := templateSource(tpl, true)
+	if err != nil {
+		return "", "", fmt.Errorf("subject template: %w", err)
+	}
+	bodySrc, err := templateSource(tpl, false)
+	if err != nil {
+		return "", "", fmt.Errorf("body template: %w", err)
+	}
+
+	subject, err = renderText("subject", subjectSrc, ctx)
+	if err != nil {
+		return "", "", err
+	}
+	body, err = renderText("body", bodySrc, ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+func templateSource(tpl *MailTemplate, wantSubject bool) (string, error) {
+	file, inline, def := "", "", defaultBodyTemplate
+	if wantSubject {
+		def = defaultSubjectTemplate
+	}
+	if tpl != nil {
+		if wantSubject {
+			file, inline = tpl.SubjectFile, tpl.Subject
+		} else {
+			file, inline = tpl.BodyFile, tpl.Body
+		}
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	if inline != "" {
+		return inline, nil
+	}
+	return def, nil
+}
+
+func renderText(name, src string, ctx TemplateContext) (string, error) {
+	tmpl, err := template.New(name).Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", fmt.Errorf("executing %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
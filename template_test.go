@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderMailTemplateDefault(t *testing.T) {
+	ctx := eventContext(NotifyEvent{Type: EventSuccess, Datastore: "ds", BackupID: "host1"})
+
+	subject, body, err := renderMailTemplate(nil, ctx)
+	if err != nil {
+		t.Fatalf("renderMailTemplate returned error: %v", err)
+	}
+	if !strings.Contains(subject, "ds/host1") {
+		t.Fatalf("subject %q missing datastore/backup id", subject)
+	}
+	if !strings.Contains(body, "success") {
+		t.Fatalf("body %q missing status", body)
+	}
+}
+
+func TestRenderMailTemplateInline(t *testing.T) {
+	tpl := &MailTemplate{Subject: "{{.Status}} for {{.Datastore}}", Body: "body for {{.BackupID}}"}
+	ctx := eventContext(NotifyEvent{Type: EventFailure, Datastore: "ds", BackupID: "host1"})
+
+	subject, body, err := renderMailTemplate(tpl, ctx)
+	if err != nil {
+		t.Fatalf("renderMailTemplate returned error: %v", err)
+	}
+	if subject != "failure for ds" {
+		t.Fatalf("got subject %q, want %q", subject, "failure for ds")
+	}
+	if body != "body for host1" {
+		t.Fatalf("got body %q, want %q", body, "body for host1")
+	}
+}
+
+func TestRenderMailTemplateFileOverridesInline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subject.tmpl")
+	if err := os.WriteFile(path, []byte("from file: {{.Datastore}}"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	tpl := &MailTemplate{Subject: "from inline", SubjectFile: path}
+	ctx := eventContext(NotifyEvent{Type: EventStart, Datastore: "ds"})
+
+	subject, _, err := renderMailTemplate(tpl, ctx)
+	if err != nil {
+		t.Fatalf("renderMailTemplate returned error: %v", err)
+	}
+	if subject != "from file: ds" {
+		t.Fatalf("got subject %q, want file contents to win over inline", subject)
+	}
+}